@@ -2,93 +2,58 @@ package main
 
 import (
 	"context"
-	"crypto/rand"
-	"crypto/rsa"
-	"encoding/base32"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
-	"strings"
 	"time"
 
 	"net/http/httputil"
 
 	"github.com/coreos/go-oidc"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/clientcredentials"
 	jose "gopkg.in/square/go-jose.v2"
 	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/taobaohi/rfc7523/pkg/rfc7523"
+	"github.com/taobaohi/rfc7523/pkg/rfc7523/jwks"
+)
+
+// keyRotationInterval and keyRetention bound how often the signing key
+// rotates and how long a retired key is still published for verification
+// of tokens signed just before a rotation.
+const (
+	keyRotationInterval = 24 * time.Hour
+	keyRetention        = 48 * time.Hour
 )
 
 func main() {
-	// json web key setup - JWK
+	// json web key setup - JWK, rotated automatically by the key ring
 
-	bits := 2048
-	pk, err := rsa.GenerateKey(rand.Reader, bits)
+	keyRing, err := rfc7523.NewKeyRing(rfc7523.KeyRingConfig{
+		Algorithm:        jose.RS256,
+		RotationInterval: keyRotationInterval,
+		Retention:        keyRetention,
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer keyRing.Stop()
 
-	use := "sig"
-	sigAlg := jose.RS256
-	var kid string
-	{
-		b := make([]byte, 5)
-		_, err := rand.Read(b)
-		if err != nil {
-			log.Fatal(err)
-		}
-		kid = base32.StdEncoding.EncodeToString(b)
-	}
-
-	privJWK := jose.JSONWebKey{
-		Key:       pk,
-		KeyID:     kid,
-		Use:       use,
-		Algorithm: string(sigAlg),
-	}
-
-	pubJWK := jose.JSONWebKey{
-		Key:       pk.Public(),
-		KeyID:     kid,
-		Use:       use,
-		Algorithm: string(sigAlg),
-	}
-
-	pubJWKS := jose.JSONWebKeySet{[]jose.JSONWebKey{pubJWK}}
-
-	// the `/jwks` endpoint hosting the JWK public key content
+	// the `/jwks` endpoint hosting the currently active and recently
+	// retired public keys
 
+	jwksHandler := jwks.Handler(keyRing)
 	http.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
-		{
-			rd, _ := httputil.DumpRequest(r, true)
-			log.Println("/jwks handler: request", string(rd))
-		}
-		_ = json.NewEncoder(w).Encode(pubJWKS)
+		rd, _ := httputil.DumpRequest(r, true)
+		log.Println("/jwks handler: request", string(rd))
+		jwksHandler.ServeHTTP(w, r)
 	})
 
 	go func() {
 		http.ListenAndServe(":8888", nil)
 	}()
 
-	signer, err := jose.NewSigner(
-		jose.SigningKey{
-			Algorithm: sigAlg,
-			Key:       privJWK,
-		},
-		&jose.SignerOptions{
-			// this will only embed the JWK's key ID "kid"
-			// the public key content is retrieved using the `/jwks` endpoint
-			EmbedJWK: false,
-		},
-	)
-	if err != nil {
-		log.Fatal(err)
-	}
-
 	// oauth
 
 	issuer := "http://localhost:8080/auth/realms/master"
@@ -99,39 +64,34 @@ func main() {
 		log.Fatal(err)
 	}
 
-	// no client ID and client secrets needed here,
-	// as the client is asserted via a signed jwt.
-	cfg := clientcredentials.Config{TokenURL: provider.Endpoint().TokenURL}
-
 	transport := &http.Transport{
 		Dial:                (&net.Dialer{Timeout: 10 * time.Second}).Dial,
 		MaxIdleConnsPerHost: 10,
 		IdleConnTimeout:     30 * time.Second,
 	}
 
-	client := &http.Client{
-		Timeout: 20 * time.Second,
-		Transport: &debugRoundTripper{
-			&jwtClientAuthenticator{
-				claims: Claims{
-					// subject needs to match the client ID,
-					// see https://github.com/keycloak/keycloak/blob/b478472b3578b8980d7b5f1642e91e75d1e78d16/services/src/main/java/org/keycloak/authentication/authenticators/client/JWTClientAuthenticator.java#L102-L105
-					Subject: "telemeter",
-
-					// audience needs to match realm issuer,
-					// see https://github.com/keycloak/keycloak/blob/b478472b3578b8980d7b5f1642e91e75d1e78d16/services/src/main/java/org/keycloak/authentication/authenticators/client/JWTClientAuthenticator.java#L142-L144
-					Audience: []string{issuer},
-				},
-
-				signer: signer,
-				next:   transport,
-			},
-		},
+	// no client ID and client secrets needed here,
+	// as the client is asserted via a signed jwt.
+	cfg := rfc7523.Config{
+		// subject needs to match the client ID,
+		// see https://github.com/keycloak/keycloak/blob/b478472b3578b8980d7b5f1642e91e75d1e78d16/services/src/main/java/org/keycloak/authentication/authenticators/client/JWTClientAuthenticator.java#L102-L105
+		Subject: "telemeter",
+
+		// audience needs to match realm issuer,
+		// see https://github.com/keycloak/keycloak/blob/b478472b3578b8980d7b5f1642e91e75d1e78d16/services/src/main/java/org/keycloak/authentication/authenticators/client/JWTClientAuthenticator.java#L142-L144
+		Audience: []string{issuer},
+
+		KeySource: keyRing,
+		Transport: &debugRoundTripper{transport},
+		Timeout:   20 * time.Second,
 	}
 
-	ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
+	// verifies the access tokens Keycloak hands back, resolving its
+	// signing keys via OIDC discovery instead of assuming a single
+	// well-known JWK.
+	verifier := jwks.NewCachingProvider(issuer)
 
-	src := cfg.TokenSource(ctx)
+	src := rfc7523.NewTokenSource(ctx, provider.Endpoint().TokenURL, cfg)
 	for {
 		tok, err := src.Token()
 		if err != nil {
@@ -145,6 +105,13 @@ func main() {
 		log.Println("--- Refresh Token")
 		fmt.Println(tok.RefreshToken)
 
+		if claims, err := verify(ctx, verifier, tok.AccessToken); err != nil {
+			log.Println("verifying access token:", err)
+		} else {
+			log.Println("--- Access Token Claims (verified against discovered JWKS)")
+			log.Println(claims)
+		}
+
 		fmt.Println("retrying in 1 minute and 30 seconds")
 		time.Sleep(30 * time.Second)
 	}
@@ -170,48 +137,27 @@ func (rt *debugRoundTripper) RoundTrip(req *http.Request) (res *http.Response, e
 	return
 }
 
-type Claims struct {
-	Issuer   string
-	Subject  string
-	Audience []string
-	ID       string
-}
-
-type jwtClientAuthenticator struct {
-	claims Claims
-	signer jose.Signer
-	next   http.RoundTripper
-}
-
-func (rt *jwtClientAuthenticator) RoundTrip(req *http.Request) (*http.Response, error) {
-	clientAuthClaims := jwt.Claims{
-		Issuer:   rt.claims.Issuer,
-		Subject:  rt.claims.Subject,
-		Audience: rt.claims.Audience,
-		ID:       rt.claims.ID,
-		IssuedAt: jwt.NewNumericDate(time.Now()),
+// verify parses raw as a signed JWT, resolves its signing key via provider
+// and, if valid, decodes and returns its claims.
+func verify(ctx context.Context, provider *jwks.CachingProvider, raw string) (map[string]interface{}, error) {
+	parsed, err := jwt.ParseSigned(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing token: %w", err)
+	}
+	if len(parsed.Headers) == 0 {
+		return nil, fmt.Errorf("token has no headers")
 	}
 
-	clientAuthJWT, err := jwt.Signed(rt.signer).Claims(clientAuthClaims).CompactSerialize()
+	key, err := provider.Key(ctx, parsed.Headers[0].KeyID)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("resolving signing key: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Del("Authorization") // replaced with client assertion
-
-	if err := req.ParseForm(); err != nil {
-		return nil, err
+	var claims map[string]interface{}
+	if err := parsed.Claims(key.Key, &claims); err != nil {
+		return nil, fmt.Errorf("verifying signature: %w", err)
 	}
-
-	req.Form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
-	req.Form.Set("client_assertion", clientAuthJWT)
-
-	newBody := req.Form.Encode()
-	req.Body = ioutil.NopCloser(strings.NewReader(string(newBody)))
-	req.ContentLength = int64(len(newBody))
-
-	return rt.next.RoundTrip(req)
+	return claims, nil
 }
 
 func mustMarshal(src json.Marshaler) []byte {
@@ -0,0 +1,45 @@
+// Package jwks provides both sides of JSON Web Key Set handling: serving a
+// JWKS from a pluggable KeyStore, and resolving a remote issuer's JWKS
+// (discovered via its .well-known/openid-configuration document) with
+// in-memory caching on the client side.
+package jwks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// KeyStore supplies the JSON Web Key Set served by Handler. Implementations
+// may hold a single static key or a rotating set; see the rfc7523 package's
+// key rotation support for the latter.
+type KeyStore interface {
+	KeySet(ctx context.Context) (jose.JSONWebKeySet, error)
+}
+
+// StaticKeyStore is a KeyStore that always serves the same key set.
+type StaticKeyStore struct {
+	Keys jose.JSONWebKeySet
+}
+
+// KeySet implements KeyStore.
+func (s StaticKeyStore) KeySet(ctx context.Context) (jose.JSONWebKeySet, error) {
+	return s.Keys, nil
+}
+
+// Handler returns an http.Handler serving store's key set as a JSON Web Key
+// Set document, suitable for mounting at a JWKS URI (e.g. "/jwks").
+func Handler(store KeyStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ks, err := store.KeySet(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ks)
+	})
+}
@@ -0,0 +1,243 @@
+package jwks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// DefaultTTL is used when a CachingProvider is constructed without
+// WithTTL.
+const DefaultTTL = 5 * time.Minute
+
+// DefaultMinRefreshInterval is used when a CachingProvider is constructed
+// without WithMinRefreshInterval.
+const DefaultMinRefreshInterval = 10 * time.Second
+
+// ProviderOption configures a CachingProvider.
+type ProviderOption func(*CachingProvider)
+
+// WithHTTPClient overrides the http.Client used for discovery and JWKS
+// fetches. Defaults to http.DefaultClient.
+func WithHTTPClient(c *http.Client) ProviderOption {
+	return func(p *CachingProvider) { p.httpClient = c }
+}
+
+// WithTTL overrides how long a fetched key set is trusted before a lookup
+// triggers a refresh. Defaults to DefaultTTL.
+func WithTTL(ttl time.Duration) ProviderOption {
+	return func(p *CachingProvider) { p.ttl = ttl }
+}
+
+// WithMinRefreshInterval overrides how long a kid that was absent from the
+// last successful fetch must wait before it can force another one.
+// Defaults to DefaultMinRefreshInterval.
+func WithMinRefreshInterval(d time.Duration) ProviderOption {
+	return func(p *CachingProvider) { p.minRefreshInterval = d }
+}
+
+// CachingProvider resolves the JSON Web Key Set used to verify tokens
+// issued by an OIDC-compliant authorization server. It discovers the JWKS
+// URI via the issuer's .well-known/openid-configuration document, caches
+// keys by "kid" for a TTL, and refreshes automatically on an unknown kid.
+// Concurrent refreshes are deduplicated via singleflight, so a burst of
+// verifications against a just-rotated key only causes one fetch.
+type CachingProvider struct {
+	issuer             string
+	httpClient         *http.Client
+	ttl                time.Duration
+	minRefreshInterval time.Duration
+
+	discoverMu sync.Mutex
+	jwksURI    string
+
+	group singleflight.Group
+
+	mu          sync.RWMutex
+	keys        map[string]jose.JSONWebKey
+	fetched     time.Time
+	lastAttempt time.Time
+}
+
+// NewCachingProvider returns a CachingProvider for the given issuer.
+func NewCachingProvider(issuer string, opts ...ProviderOption) *CachingProvider {
+	p := &CachingProvider{
+		issuer:             issuer,
+		httpClient:         http.DefaultClient,
+		ttl:                DefaultTTL,
+		minRefreshInterval: DefaultMinRefreshInterval,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Key returns the JSON Web Key for kid, fetching (or refreshing) the
+// issuer's key set as needed. A kid that was absent from the last
+// successful fetch cannot force another one more often than
+// minRefreshInterval, so a burst of bogus or replayed kids can't be used
+// to hammer the issuer's discovery and JWKS endpoints on every lookup.
+func (p *CachingProvider) Key(ctx context.Context, kid string) (jose.JSONWebKey, error) {
+	if k, ok := p.cached(kid); ok {
+		return k, nil
+	}
+	if !p.known(kid) && time.Since(p.lastAttemptAt()) < p.minRefreshInterval {
+		return jose.JSONWebKey{}, fmt.Errorf("jwks: key %q not found for issuer %s (refresh rate-limited)", kid, p.issuer)
+	}
+	return p.refresh(ctx, kid)
+}
+
+// KeyFunc adapts Key into a bare kid -> key lookup, usable by go-jose
+// verifiers (e.g. resolving the key for jwt.Claims).
+func (p *CachingProvider) KeyFunc(ctx context.Context) func(kid string) (interface{}, error) {
+	return func(kid string) (interface{}, error) {
+		k, err := p.Key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		return k.Key, nil
+	}
+}
+
+func (p *CachingProvider) cached(kid string) (jose.JSONWebKey, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if time.Since(p.fetched) > p.ttl {
+		return jose.JSONWebKey{}, false
+	}
+	k, ok := p.keys[kid]
+	return k, ok
+}
+
+// known reports whether kid was present in the last successful fetch,
+// regardless of whether that fetch's TTL has since expired.
+func (p *CachingProvider) known(kid string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	_, ok := p.keys[kid]
+	return ok
+}
+
+func (p *CachingProvider) lastAttemptAt() time.Time {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.lastAttempt
+}
+
+func (p *CachingProvider) refresh(ctx context.Context, kid string) (jose.JSONWebKey, error) {
+	v, err, _ := p.group.Do("refresh", func() (interface{}, error) {
+		return p.fetch(ctx)
+	})
+	if err != nil {
+		return jose.JSONWebKey{}, err
+	}
+
+	keys := v.(map[string]jose.JSONWebKey)
+	k, ok := keys[kid]
+	if !ok {
+		// The fetch succeeded but genuinely has no such kid; mark this
+		// as the last forced-refresh attempt so a repeat of the same
+		// (bogus or replayed) kid can't force another fetch until
+		// minRefreshInterval has passed.
+		p.mu.Lock()
+		p.lastAttempt = time.Now()
+		p.mu.Unlock()
+		return jose.JSONWebKey{}, fmt.Errorf("jwks: key %q not found for issuer %s", kid, p.issuer)
+	}
+	return k, nil
+}
+
+func (p *CachingProvider) fetch(ctx context.Context) (map[string]jose.JSONWebKey, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, fmt.Errorf("jwks: discovering issuer %s: %w", p.issuer, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: fetching %s: unexpected status %s", p.jwksURI, resp.Status)
+	}
+
+	var ks jose.JSONWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&ks); err != nil {
+		return nil, fmt.Errorf("jwks: decoding %s: %w", p.jwksURI, err)
+	}
+
+	keys := make(map[string]jose.JSONWebKey, len(ks.Keys))
+	for _, k := range ks.Keys {
+		keys[k.KeyID] = k
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.fetched = time.Now()
+	p.mu.Unlock()
+
+	return keys, nil
+}
+
+type discoveryDocument struct {
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// discover resolves and caches the issuer's JWKS URI. A successful result
+// is cached for the lifetime of the provider, but a failed attempt is not:
+// a provider constructed before its issuer is reachable (or hitting a
+// transient 5xx) retries discovery on the next call instead of being
+// stuck failing for the rest of the process's life.
+func (p *CachingProvider) discover(ctx context.Context) error {
+	p.discoverMu.Lock()
+	defer p.discoverMu.Unlock()
+
+	if p.jwksURI != "" {
+		return nil
+	}
+
+	discoveryURL := strings.TrimSuffix(p.issuer, "/") + "/.well-known/openid-configuration"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("discovery document %s: unexpected status %s", discoveryURL, resp.Status)
+	}
+
+	var doc discoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return err
+	}
+	if doc.JWKSURI == "" {
+		return fmt.Errorf("discovery document %s has no jwks_uri", discoveryURL)
+	}
+
+	p.jwksURI = doc.JWKSURI
+	return nil
+}
@@ -0,0 +1,135 @@
+package jwks
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func testJWK(t *testing.T, kid string) jose.JSONWebKey {
+	t.Helper()
+
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating test key: %v", err)
+	}
+	return jose.JSONWebKey{Key: pk.Public(), KeyID: kid, Use: "sig", Algorithm: "RS256"}
+}
+
+// newTestIssuer starts an issuer serving openid-configuration discovery and
+// a JWKS endpoint. discoveryFailures controls how many discovery requests
+// fail with a 500 before succeeding.
+func newTestIssuer(t *testing.T, keySet jose.JSONWebKeySet, discoveryFailures int) (srv *httptest.Server, discoveryHits, jwksHits *int32) {
+	t.Helper()
+
+	discoveryHits = new(int32)
+	jwksHits = new(int32)
+
+	mux := http.NewServeMux()
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		if int(atomic.AddInt32(discoveryHits, 1)) <= discoveryFailures {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"jwks_uri": srv.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(jwksHits, 1)
+		_ = json.NewEncoder(w).Encode(keySet)
+	})
+
+	return srv, discoveryHits, jwksHits
+}
+
+func TestCachingProviderServesFromCacheWithinTTL(t *testing.T) {
+	keySet := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{testJWK(t, "kid-1")}}
+	srv, _, jwksHits := newTestIssuer(t, keySet, 0)
+
+	p := NewCachingProvider(srv.URL, WithHTTPClient(srv.Client()), WithTTL(time.Hour))
+
+	for i := 0; i < 3; i++ {
+		if _, err := p.Key(context.Background(), "kid-1"); err != nil {
+			t.Fatalf("Key: %v", err)
+		}
+	}
+
+	if got := atomic.LoadInt32(jwksHits); got != 1 {
+		t.Fatalf("expected a single JWKS fetch within the TTL, got %d", got)
+	}
+}
+
+func TestCachingProviderRefreshesKnownKidAfterTTLExpiry(t *testing.T) {
+	keySet := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{testJWK(t, "kid-1")}}
+	srv, _, jwksHits := newTestIssuer(t, keySet, 0)
+
+	p := NewCachingProvider(srv.URL, WithHTTPClient(srv.Client()), WithTTL(-time.Second))
+
+	if _, err := p.Key(context.Background(), "kid-1"); err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+	if _, err := p.Key(context.Background(), "kid-1"); err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+
+	if got := atomic.LoadInt32(jwksHits); got != 2 {
+		t.Fatalf("expected a fetch per lookup once the TTL has expired, got %d", got)
+	}
+}
+
+func TestCachingProviderRetriesDiscoveryAfterFailure(t *testing.T) {
+	keySet := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{testJWK(t, "kid-1")}}
+	srv, discoveryHits, _ := newTestIssuer(t, keySet, 1)
+
+	p := NewCachingProvider(srv.URL, WithHTTPClient(srv.Client()))
+
+	if _, err := p.Key(context.Background(), "kid-1"); err == nil {
+		t.Fatal("expected the lookup to fail while discovery is down")
+	}
+
+	if _, err := p.Key(context.Background(), "kid-1"); err != nil {
+		t.Fatalf("expected the lookup to succeed once discovery recovers, got %v", err)
+	}
+
+	if got := atomic.LoadInt32(discoveryHits); got != 2 {
+		t.Fatalf("expected discovery to be retried rather than cached, got %d attempts", got)
+	}
+}
+
+func TestCachingProviderRateLimitsRefreshesForUnknownKid(t *testing.T) {
+	keySet := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{testJWK(t, "kid-1")}}
+	srv, _, jwksHits := newTestIssuer(t, keySet, 0)
+
+	p := NewCachingProvider(srv.URL, WithHTTPClient(srv.Client()), WithMinRefreshInterval(time.Hour))
+
+	for i := 0; i < 3; i++ {
+		if _, err := p.Key(context.Background(), "does-not-exist"); err == nil {
+			t.Fatal("expected an error for an unknown kid")
+		}
+	}
+
+	if got := atomic.LoadInt32(jwksHits); got != 1 {
+		t.Fatalf("expected only the first lookup to force a fetch, got %d fetches", got)
+	}
+}
+
+func TestCachingProviderKeyUnknownKid(t *testing.T) {
+	keySet := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{testJWK(t, "kid-1")}}
+	srv, _, _ := newTestIssuer(t, keySet, 0)
+
+	p := NewCachingProvider(srv.URL, WithHTTPClient(srv.Client()))
+
+	if _, err := p.Key(context.Background(), "does-not-exist"); err == nil {
+		t.Fatal("expected an error for an unknown kid")
+	}
+}
@@ -0,0 +1,159 @@
+// Package jwtbearer implements the OAuth 2.0 JWT-bearer authorization
+// grant described in RFC 7523 §2.1: a signed JWT assertion identifying the
+// subject being acted on behalf of is exchanged directly for an access
+// token. This is distinct from the client-assertion authentication method
+// in the rfc7523 package (§2.2); the two are often used together, e.g. a
+// service account authenticating itself with a client assertion while
+// requesting a token via the jwt-bearer grant on behalf of a user.
+//
+// Config mirrors the shape of golang.org/x/oauth2/jwt.Config, but sources
+// its signer from an rfc7523.KeySource rather than a raw private key, so
+// it composes with the same key rotation and HSM/KMS-backed signers.
+package jwtbearer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/taobaohi/rfc7523/pkg/rfc7523"
+)
+
+// grantType is the urn registered for the RFC 7523 §2.1 grant.
+const grantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+
+// Config describes a JWT-bearer grant (RFC 7523 §2.1): a signed assertion
+// naming the subject being delegated for is exchanged for an access
+// token, enabling service-to-service delegation flows without a user
+// present at request time.
+type Config struct {
+	// TokenURL is the authorization server's token endpoint.
+	TokenURL string
+
+	// Issuer populates the "iss" claim, identifying the party making
+	// the assertion (e.g. a service account or OAuth client ID).
+	Issuer string
+
+	// Subject populates the "sub" claim: the identity being
+	// impersonated or delegated for.
+	Subject string
+
+	// Audience identifies the token endpoint's issuer, typically its
+	// issuer URL.
+	Audience []string
+
+	// Scope, if non-empty, is requested via the "scope" form
+	// parameter.
+	Scope []string
+
+	// KeySource supplies the signer used for each assertion.
+	KeySource rfc7523.KeySource
+
+	// AssertionLifetime bounds how long each signed assertion is valid
+	// for. Defaults to rfc7523.DefaultAssertionLifetime.
+	AssertionLifetime time.Duration
+}
+
+// TokenSource returns an oauth2.TokenSource that performs the RFC 7523
+// §2.1 JWT-bearer grant against cfg.TokenURL, signing a fresh assertion
+// each time the token needs renewing. If ctx carries an oauth2.HTTPClient
+// value, that client is used to perform the token request.
+func (cfg Config) TokenSource(ctx context.Context) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, tokenSource{ctx: ctx, cfg: cfg})
+}
+
+type tokenSource struct {
+	ctx context.Context
+	cfg Config
+}
+
+func (ts tokenSource) Token() (*oauth2.Token, error) {
+	cfg := ts.cfg
+
+	signer, err := cfg.KeySource.Signer(ts.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("jwtbearer: obtaining signer: %w", err)
+	}
+
+	lifetime := cfg.AssertionLifetime
+	if lifetime <= 0 {
+		lifetime = rfc7523.DefaultAssertionLifetime
+	}
+
+	now := time.Now()
+	claims := jwt.Claims{
+		Issuer:    cfg.Issuer,
+		Subject:   cfg.Subject,
+		Audience:  cfg.Audience,
+		ID:        rfc7523.NewJTI(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		Expiry:    jwt.NewNumericDate(now.Add(lifetime)),
+	}
+
+	assertion, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		return nil, fmt.Errorf("jwtbearer: signing assertion: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {grantType},
+		"assertion":  {assertion},
+	}
+	if len(cfg.Scope) > 0 {
+		form.Set("scope", strings.Join(cfg.Scope, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ts.ctx, http.MethodPost, cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := http.DefaultClient
+	if c, ok := ts.ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+		client = c
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwtbearer: requesting token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("jwtbearer: reading token response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("jwtbearer: token endpoint returned %s: %s", resp.Status, body)
+	}
+
+	var tokRes struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tokRes); err != nil {
+		return nil, fmt.Errorf("jwtbearer: decoding token response: %w", err)
+	}
+
+	tok := &oauth2.Token{
+		AccessToken:  tokRes.AccessToken,
+		TokenType:    tokRes.TokenType,
+		RefreshToken: tokRes.RefreshToken,
+	}
+	if tokRes.ExpiresIn > 0 {
+		tok.Expiry = now.Add(time.Duration(tokRes.ExpiresIn) * time.Second)
+	}
+	return tok, nil
+}
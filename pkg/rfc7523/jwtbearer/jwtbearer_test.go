@@ -0,0 +1,73 @@
+package jwtbearer
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/taobaohi/rfc7523/pkg/rfc7523"
+)
+
+func TestTokenSourceAssertionHasUniqueJTI(t *testing.T) {
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating test key: %v", err)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: pk}, nil)
+	if err != nil {
+		t.Fatalf("jose.NewSigner: %v", err)
+	}
+
+	var jtis []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatalf("ParseForm: %v", err)
+		}
+		tok, err := jwt.ParseSigned(r.Form.Get("assertion"))
+		if err != nil {
+			t.Fatalf("ParseSigned: %v", err)
+		}
+		var claims jwt.Claims
+		if err := tok.Claims(&pk.PublicKey, &claims); err != nil {
+			t.Fatalf("Claims: %v", err)
+		}
+		if claims.ID == "" {
+			t.Fatal("expected a non-empty jti claim")
+		}
+		jtis = append(jtis, claims.ID)
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	}))
+	defer srv.Close()
+
+	cfg := Config{
+		TokenURL:  srv.URL,
+		Issuer:    "client-id",
+		Subject:   "delegated-user",
+		Audience:  []string{srv.URL},
+		KeySource: rfc7523.StaticKeySource{Key: signer},
+	}
+
+	src := tokenSource{ctx: context.Background(), cfg: cfg}
+	if _, err := src.Token(); err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if _, err := src.Token(); err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+
+	if len(jtis) != 2 || jtis[0] == jtis[1] {
+		t.Fatalf("expected two distinct jti values, got %v", jtis)
+	}
+}
@@ -0,0 +1,208 @@
+package rfc7523
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base32"
+	"fmt"
+	"sync"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// DefaultRotationInterval and DefaultRetention are used by NewKeyRing when
+// left zero.
+const (
+	DefaultRotationInterval = 24 * time.Hour
+	DefaultRetention        = 48 * time.Hour
+)
+
+// KeyRingConfig configures a KeyRing.
+type KeyRingConfig struct {
+	// Algorithm selects the signature algorithm for generated keys.
+	// Supported: jose.RS256, jose.PS256, jose.ES256. Defaults to
+	// jose.RS256.
+	Algorithm jose.SignatureAlgorithm
+
+	// RotationInterval is how often a new signing key is generated.
+	// Defaults to DefaultRotationInterval.
+	RotationInterval time.Duration
+
+	// Retention is how long a retired key stays published (and
+	// therefore usable to verify tokens signed before the rotation
+	// that retired it). Defaults to DefaultRetention.
+	Retention time.Duration
+}
+
+// KeyRing holds a ring of signing keys with staggered lifetimes: at any
+// moment it has one active (currently-signing) key plus zero or more
+// retired keys still within their retention window. It doubles as a
+// KeySource, always signing with the current key, and as the KeyStore
+// shape the jwks package expects, publishing the active key alongside any
+// retired-but-still-valid ones so in-flight requests signed just before a
+// rotation can still be verified.
+type KeyRing struct {
+	alg       jose.SignatureAlgorithm
+	rotation  time.Duration
+	retention time.Duration
+	done      chan struct{}
+
+	mu      sync.RWMutex
+	active  ringKey
+	retired []ringKey
+}
+
+type ringKey struct {
+	pub       jose.JSONWebKey
+	signer    jose.Signer
+	rotatedAt time.Time
+}
+
+// NewKeyRing creates a KeyRing with a freshly generated initial key and
+// starts its rotation timer in the background. Call Stop to release it.
+func NewKeyRing(cfg KeyRingConfig) (*KeyRing, error) {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = jose.RS256
+	}
+	if cfg.RotationInterval <= 0 {
+		cfg.RotationInterval = DefaultRotationInterval
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = DefaultRetention
+	}
+
+	initial, err := newRingKey(cfg.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	kr := &KeyRing{
+		alg:       cfg.Algorithm,
+		rotation:  cfg.RotationInterval,
+		retention: cfg.Retention,
+		done:      make(chan struct{}),
+		active:    initial,
+	}
+
+	go kr.rotateLoop()
+
+	return kr, nil
+}
+
+// Signer implements KeySource. It returns the signer for the currently
+// active key, selected atomically so a request in flight during a
+// rotation always gets a consistent, fully-formed signer.
+func (kr *KeyRing) Signer(ctx context.Context) (jose.Signer, error) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+	return kr.active.signer, nil
+}
+
+// KeySet implements the KeyStore shape expected by the jwks package: the
+// active key's public JWK plus any retired keys still within retention.
+func (kr *KeyRing) KeySet(ctx context.Context) (jose.JSONWebKeySet, error) {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	keys := make([]jose.JSONWebKey, 0, 1+len(kr.retired))
+	keys = append(keys, kr.active.pub)
+	for _, k := range kr.retired {
+		keys = append(keys, k.pub)
+	}
+	return jose.JSONWebKeySet{Keys: keys}, nil
+}
+
+// Stop halts the rotation timer. The ring continues to serve whatever
+// keys it last held.
+func (kr *KeyRing) Stop() {
+	close(kr.done)
+}
+
+func (kr *KeyRing) rotateLoop() {
+	ticker := time.NewTicker(kr.rotation)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			kr.rotate()
+		case <-kr.done:
+			return
+		}
+	}
+}
+
+func (kr *KeyRing) rotate() {
+	next, err := newRingKey(kr.alg)
+	if err != nil {
+		// Keep signing with the current key and try again next tick.
+		return
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	retiring := kr.active
+	retiring.rotatedAt = time.Now()
+	kr.retired = append(kr.retired, retiring)
+	kr.active = next
+
+	cutoff := time.Now().Add(-kr.retention)
+	live := kr.retired[:0]
+	for _, k := range kr.retired {
+		if k.rotatedAt.After(cutoff) {
+			live = append(live, k)
+		}
+	}
+	kr.retired = live
+}
+
+func newRingKey(alg jose.SignatureAlgorithm) (ringKey, error) {
+	kid, err := newKeyID()
+	if err != nil {
+		return ringKey{}, err
+	}
+
+	var privKey, pubKey interface{}
+	switch alg {
+	case jose.RS256, jose.PS256:
+		pk, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return ringKey{}, err
+		}
+		privKey, pubKey = pk, pk.Public()
+	case jose.ES256:
+		pk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return ringKey{}, err
+		}
+		privKey, pubKey = pk, pk.Public()
+	default:
+		return ringKey{}, fmt.Errorf("rfc7523: unsupported key rotation algorithm %s", alg)
+	}
+
+	priv := jose.JSONWebKey{Key: privKey, KeyID: kid, Use: "sig", Algorithm: string(alg)}
+	pub := jose.JSONWebKey{Key: pubKey, KeyID: kid, Use: "sig", Algorithm: string(alg)}
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: alg, Key: priv},
+		&jose.SignerOptions{EmbedJWK: false},
+	)
+	if err != nil {
+		return ringKey{}, err
+	}
+
+	return ringKey{pub: pub, signer: signer, rotatedAt: time.Now()}, nil
+}
+
+func newKeyID() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.EncodeToString(b), nil
+}
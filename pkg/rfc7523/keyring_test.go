@@ -0,0 +1,108 @@
+package rfc7523
+
+import (
+	"testing"
+	"time"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func TestKeyRingRotateEvictsOnlyKeysPastRetention(t *testing.T) {
+	active, err := newRingKey(jose.RS256)
+	if err != nil {
+		t.Fatalf("newRingKey: %v", err)
+	}
+
+	expired, err := newRingKey(jose.RS256)
+	if err != nil {
+		t.Fatalf("newRingKey: %v", err)
+	}
+	expired.rotatedAt = time.Now().Add(-2 * time.Hour)
+
+	stillValid, err := newRingKey(jose.RS256)
+	if err != nil {
+		t.Fatalf("newRingKey: %v", err)
+	}
+	stillValid.rotatedAt = time.Now().Add(-30 * time.Minute)
+
+	kr := &KeyRing{
+		alg:       jose.RS256,
+		retention: time.Hour,
+		active:    active,
+		retired:   []ringKey{expired, stillValid},
+	}
+
+	kr.rotate()
+
+	// expired falls outside the retention window and should have been
+	// evicted; stillValid is retained, and active joins the retired set
+	// as the key that was just replaced.
+	if len(kr.retired) != 2 {
+		t.Fatalf("expected 2 retired keys after rotate, got %d", len(kr.retired))
+	}
+	for _, k := range kr.retired {
+		if k.pub.KeyID == expired.pub.KeyID {
+			t.Fatalf("retired key %s should have been evicted past the retention window", expired.pub.KeyID)
+		}
+	}
+
+	found := map[string]bool{}
+	for _, k := range kr.retired {
+		found[k.pub.KeyID] = true
+	}
+	if !found[stillValid.pub.KeyID] {
+		t.Fatalf("retired key %s within the retention window should have been kept", stillValid.pub.KeyID)
+	}
+	if !found[active.pub.KeyID] {
+		t.Fatalf("previously active key %s should have been retired", active.pub.KeyID)
+	}
+
+	if kr.active.pub.KeyID == active.pub.KeyID {
+		t.Fatal("rotate should have replaced the active key")
+	}
+}
+
+func TestKeyRingSignerReturnsActiveSigner(t *testing.T) {
+	kr := &KeyRing{alg: jose.RS256}
+
+	initial, err := newRingKey(jose.RS256)
+	if err != nil {
+		t.Fatalf("newRingKey: %v", err)
+	}
+	kr.active = initial
+
+	signer, err := kr.Signer(nil)
+	if err != nil {
+		t.Fatalf("Signer: %v", err)
+	}
+	if signer != initial.signer {
+		t.Fatal("Signer should return the active key's signer")
+	}
+}
+
+func TestKeyRingKeySetIncludesActiveAndRetiredKeys(t *testing.T) {
+	active, err := newRingKey(jose.RS256)
+	if err != nil {
+		t.Fatalf("newRingKey: %v", err)
+	}
+	retired, err := newRingKey(jose.RS256)
+	if err != nil {
+		t.Fatalf("newRingKey: %v", err)
+	}
+
+	kr := &KeyRing{active: active, retired: []ringKey{retired}}
+
+	ks, err := kr.KeySet(nil)
+	if err != nil {
+		t.Fatalf("KeySet: %v", err)
+	}
+	if len(ks.Keys) != 2 {
+		t.Fatalf("expected 2 published keys, got %d", len(ks.Keys))
+	}
+
+	ids := map[string]bool{ks.Keys[0].KeyID: true, ks.Keys[1].KeyID: true}
+	if !ids[active.pub.KeyID] || !ids[retired.pub.KeyID] {
+		t.Fatalf("expected both active (%s) and retired (%s) key IDs published, got %v",
+			active.pub.KeyID, retired.pub.KeyID, ids)
+	}
+}
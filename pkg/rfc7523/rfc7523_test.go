@@ -0,0 +1,124 @@
+package rfc7523
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+type capturingRoundTripper struct {
+	req  *http.Request
+	resp *http.Response
+}
+
+func (c *capturingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.req = req
+	return c.resp, nil
+}
+
+func newTestAuthenticator(t *testing.T) (*Authenticator, *rsa.PrivateKey, *capturingRoundTripper) {
+	t.Helper()
+
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating test key: %v", err)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: pk}, nil)
+	if err != nil {
+		t.Fatalf("jose.NewSigner: %v", err)
+	}
+
+	next := &capturingRoundTripper{resp: httptest.NewRecorder().Result()}
+	a := &Authenticator{
+		Config: Config{
+			Issuer:    "client-id",
+			Subject:   "client-id",
+			Audience:  []string{"https://issuer.example/token"},
+			KeySource: StaticKeySource{Key: signer},
+		},
+		Next: next,
+	}
+	return a, pk, next
+}
+
+func TestAuthenticatorRoundTripSetsClientAssertion(t *testing.T) {
+	a, pk, next := newTestAuthenticator(t)
+
+	req, err := http.NewRequest(http.MethodPost, "https://issuer.example/token", strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+
+	if _, err := a.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip: %v", err)
+	}
+
+	if err := next.req.ParseForm(); err != nil {
+		t.Fatalf("ParseForm: %v", err)
+	}
+	if got := next.req.Form.Get("client_assertion_type"); got != "urn:ietf:params:oauth:client-assertion-type:jwt-bearer" {
+		t.Fatalf("unexpected client_assertion_type: %s", got)
+	}
+
+	assertion := next.req.Form.Get("client_assertion")
+	if assertion == "" {
+		t.Fatal("expected a client_assertion to be set")
+	}
+
+	tok, err := jwt.ParseSigned(assertion)
+	if err != nil {
+		t.Fatalf("ParseSigned: %v", err)
+	}
+	var claims jwt.Claims
+	if err := tok.Claims(&pk.PublicKey, &claims); err != nil {
+		t.Fatalf("Claims: %v", err)
+	}
+
+	if claims.ID == "" {
+		t.Fatal("expected a non-empty jti claim")
+	}
+	if claims.IssuedAt == nil || claims.NotBefore == nil || claims.Expiry == nil {
+		t.Fatal("expected iat, nbf and exp claims to be populated")
+	}
+	if !claims.Expiry.Time().After(claims.IssuedAt.Time()) {
+		t.Fatal("expected exp to be after iat")
+	}
+}
+
+func TestAuthenticatorRoundTripUsesUniqueJTIPerRequest(t *testing.T) {
+	a, _, next := newTestAuthenticator(t)
+
+	var jtis []string
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodPost, "https://issuer.example/token", strings.NewReader(""))
+		if err != nil {
+			t.Fatalf("NewRequest: %v", err)
+		}
+		if _, err := a.RoundTrip(req); err != nil {
+			t.Fatalf("RoundTrip: %v", err)
+		}
+		if err := next.req.ParseForm(); err != nil {
+			t.Fatalf("ParseForm: %v", err)
+		}
+
+		tok, err := jwt.ParseSigned(next.req.Form.Get("client_assertion"))
+		if err != nil {
+			t.Fatalf("ParseSigned: %v", err)
+		}
+		var claims jwt.Claims
+		if err := tok.UnsafeClaimsWithoutVerification(&claims); err != nil {
+			t.Fatalf("UnsafeClaimsWithoutVerification: %v", err)
+		}
+		jtis = append(jtis, claims.ID)
+	}
+
+	if jtis[0] == jtis[1] {
+		t.Fatal("expected each assertion to carry a unique jti")
+	}
+}
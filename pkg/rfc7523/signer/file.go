@@ -0,0 +1,43 @@
+package signer
+
+import (
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/youmark/pkcs8"
+	jose "gopkg.in/square/go-jose.v2"
+
+	"github.com/taobaohi/rfc7523/pkg/rfc7523"
+)
+
+// FromEncryptedPEMFile reads a PKCS#8-encrypted private key from the PEM
+// file at path, decrypts it with password, and returns an rfc7523.KeySource
+// that signs with it under kid/alg. Useful when keys are provisioned as
+// files on disk rather than generated in-process.
+func FromEncryptedPEMFile(path string, password []byte, kid string, alg jose.SignatureAlgorithm) (rfc7523.KeySource, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("rfc7523/signer: reading %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("rfc7523/signer: %s contains no PEM block", path)
+	}
+
+	key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, password)
+	if err != nil {
+		return nil, fmt.Errorf("rfc7523/signer: decrypting %s: %w", path, err)
+	}
+
+	jwk := jose.JSONWebKey{Key: key, KeyID: kid, Use: "sig", Algorithm: string(alg)}
+	s, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: alg, Key: jwk},
+		&jose.SignerOptions{EmbedJWK: false},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("rfc7523/signer: building signer for %s: %w", path, err)
+	}
+	return rfc7523.StaticKeySource{Key: s}, nil
+}
@@ -0,0 +1,58 @@
+package signer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	jose "gopkg.in/square/go-jose.v2"
+
+	"github.com/taobaohi/rfc7523/pkg/rfc7523"
+)
+
+// FromJWKFile reads a single JWK or a JWKS from path and returns an
+// rfc7523.KeySource signing with it under alg. When the file holds a set,
+// the first key with Use == "sig" is preferred, falling back to the first
+// key present.
+func FromJWKFile(path string, alg jose.SignatureAlgorithm) (rfc7523.KeySource, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("rfc7523/signer: reading %s: %w", path, err)
+	}
+
+	jwk, err := selectSigningKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("rfc7523/signer: %s: %w", path, err)
+	}
+
+	s, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: alg, Key: jwk},
+		&jose.SignerOptions{EmbedJWK: false},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("rfc7523/signer: building signer for %s: %w", path, err)
+	}
+	return rfc7523.StaticKeySource{Key: s}, nil
+}
+
+func selectSigningKey(raw []byte) (jose.JSONWebKey, error) {
+	var jwk jose.JSONWebKey
+	if err := json.Unmarshal(raw, &jwk); err == nil && jwk.Key != nil {
+		return jwk, nil
+	}
+
+	var set jose.JSONWebKeySet
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return jose.JSONWebKey{}, fmt.Errorf("not a JWK or JWKS: %w", err)
+	}
+
+	for _, k := range set.Keys {
+		if k.Use == "sig" {
+			return k, nil
+		}
+	}
+	if len(set.Keys) > 0 {
+		return set.Keys[0], nil
+	}
+	return jose.JSONWebKey{}, fmt.Errorf("empty key set")
+}
@@ -0,0 +1,82 @@
+package signer
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+func testJWK(t *testing.T, kid, use string) jose.JSONWebKey {
+	t.Helper()
+
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating test key: %v", err)
+	}
+	return jose.JSONWebKey{Key: pk, KeyID: kid, Use: use, Algorithm: "RS256"}
+}
+
+func TestSelectSigningKeyFromSingleJWK(t *testing.T) {
+	jwk := testJWK(t, "kid-1", "sig")
+
+	raw, err := json.Marshal(jwk)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	got, err := selectSigningKey(raw)
+	if err != nil {
+		t.Fatalf("selectSigningKey: %v", err)
+	}
+	if got.KeyID != "kid-1" {
+		t.Fatalf("expected kid-1, got %s", got.KeyID)
+	}
+}
+
+func TestSelectSigningKeyPrefersSigUseFromJWKS(t *testing.T) {
+	set := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		testJWK(t, "enc-key", "enc"),
+		testJWK(t, "sig-key", "sig"),
+	}}
+
+	raw, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	got, err := selectSigningKey(raw)
+	if err != nil {
+		t.Fatalf("selectSigningKey: %v", err)
+	}
+	if got.KeyID != "sig-key" {
+		t.Fatalf("expected sig-key to be preferred, got %s", got.KeyID)
+	}
+}
+
+func TestSelectSigningKeyFallsBackToFirstKeyInJWKS(t *testing.T) {
+	set := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		testJWK(t, "only-key", ""),
+	}}
+
+	raw, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	got, err := selectSigningKey(raw)
+	if err != nil {
+		t.Fatalf("selectSigningKey: %v", err)
+	}
+	if got.KeyID != "only-key" {
+		t.Fatalf("expected only-key, got %s", got.KeyID)
+	}
+}
+
+func TestSelectSigningKeyErrorsOnGarbage(t *testing.T) {
+	if _, err := selectSigningKey([]byte("not json")); err == nil {
+		t.Fatal("expected an error for unparseable input")
+	}
+}
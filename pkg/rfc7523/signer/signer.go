@@ -0,0 +1,32 @@
+// Package signer provides rfc7523.KeySource implementations that source
+// signing keys from places other than an in-memory generated key: files
+// on disk (PKCS#8-encrypted PEM, JWK/JWKS) and crypto.Signer handles onto
+// external key custodians (AWS KMS, GCP KMS, HashiCorp Vault Transit,
+// PKCS#11 HSMs), so private key material never has to live in Go memory.
+package signer
+
+import (
+	"crypto"
+
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/cryptosigner"
+
+	"github.com/taobaohi/rfc7523/pkg/rfc7523"
+)
+
+// FromCryptoSigner adapts any crypto.Signer — e.g. a handle onto an AWS
+// KMS key, a GCP KMS key, a HashiCorp Vault Transit key, or a PKCS#11 HSM
+// session — into an rfc7523.KeySource. The private key material never
+// crosses into this process; every signature is produced by cs.Sign.
+func FromCryptoSigner(kid string, alg jose.SignatureAlgorithm, cs crypto.Signer) (rfc7523.KeySource, error) {
+	opts := (&jose.SignerOptions{EmbedJWK: false}).WithHeader("kid", kid)
+
+	s, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: alg, Key: cryptosigner.Opaque(cs)},
+		opts,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rfc7523.StaticKeySource{Key: s}, nil
+}
@@ -0,0 +1,185 @@
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	jose "gopkg.in/square/go-jose.v2"
+
+	"github.com/taobaohi/rfc7523/pkg/rfc7523"
+)
+
+// DefaultVaultRenewInterval is used by NewVaultKeySource when
+// VaultConfig.RenewInterval is left zero.
+const DefaultVaultRenewInterval = 30 * time.Minute
+
+// VaultConfig configures a signer backed by a HashiCorp Vault Transit
+// signing key. cfg.Client must already be authenticated (token or
+// AppRole auth method); NewVaultKeySource only takes over renewing that
+// token so long-running processes don't lose access to the key mid-flight.
+type VaultConfig struct {
+	Client *vaultapi.Client
+
+	// KeyName is the name of the Transit signing key.
+	KeyName string
+
+	// KeyID is the "kid" advertised in the JWT header.
+	KeyID string
+
+	// Algorithm is the JOSE signature algorithm the Transit key
+	// produces, e.g. jose.RS256.
+	Algorithm jose.SignatureAlgorithm
+
+	// RenewInterval is how often the Vault client token is renewed.
+	// Defaults to DefaultVaultRenewInterval.
+	RenewInterval time.Duration
+}
+
+// NewVaultKeySource returns an rfc7523.KeySource that signs via a Vault
+// Transit key, never bringing the private key material out of Vault, and
+// periodically renews cfg.Client's token in the background.
+func NewVaultKeySource(ctx context.Context, cfg VaultConfig) (rfc7523.KeySource, error) {
+	pub, err := fetchTransitPublicKey(cfg.Client, cfg.KeyName)
+	if err != nil {
+		return nil, fmt.Errorf("rfc7523/signer: fetching vault transit key %q: %w", cfg.KeyName, err)
+	}
+
+	interval := cfg.RenewInterval
+	if interval <= 0 {
+		interval = DefaultVaultRenewInterval
+	}
+	go renewVaultTokenLoop(ctx, cfg.Client, interval)
+
+	return FromCryptoSigner(cfg.KeyID, cfg.Algorithm, &transitSigner{
+		client:  cfg.Client,
+		keyName: cfg.KeyName,
+		pub:     pub,
+		alg:     cfg.Algorithm,
+	})
+}
+
+// transitSigner implements crypto.Signer by delegating to Vault's
+// transit/sign endpoint; the private key never leaves Vault.
+type transitSigner struct {
+	client  *vaultapi.Client
+	keyName string
+	pub     crypto.PublicKey
+	alg     jose.SignatureAlgorithm
+}
+
+func (s *transitSigner) Public() crypto.PublicKey { return s.pub }
+
+func (s *transitSigner) Sign(_ io.Reader, digest []byte, _ crypto.SignerOpts) ([]byte, error) {
+	params := map[string]interface{}{
+		"input":     base64.StdEncoding.EncodeToString(digest),
+		"prehashed": true,
+	}
+	if sigAlg, ok := vaultSignatureAlgorithm(s.alg); ok {
+		params["signature_algorithm"] = sigAlg
+	}
+
+	secret, err := s.client.Logical().Write(fmt.Sprintf("transit/sign/%s", s.keyName), params)
+	if err != nil {
+		return nil, fmt.Errorf("vault transit sign: %w", err)
+	}
+
+	raw, _ := secret.Data["signature"].(string)
+	parts := strings.SplitN(raw, ":", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("vault transit sign: unexpected signature format %q", raw)
+	}
+	return base64.StdEncoding.DecodeString(parts[2])
+}
+
+// vaultSignatureAlgorithm maps a JOSE signature algorithm to the
+// signature_algorithm parameter Vault's transit/sign endpoint expects for
+// RSA keys. Without it Vault defaults to "pss", silently producing a
+// signature that fails verification against an RS256/384/512-configured
+// audience. The bool is false for algorithms (e.g. ECDSA) where Vault has
+// no such parameter and the default is correct.
+func vaultSignatureAlgorithm(alg jose.SignatureAlgorithm) (string, bool) {
+	switch alg {
+	case jose.RS256, jose.RS384, jose.RS512:
+		return "pkcs1v15", true
+	case jose.PS256, jose.PS384, jose.PS512:
+		return "pss", true
+	default:
+		return "", false
+	}
+}
+
+// fetchTransitPublicKey reads the public key of the transit key's
+// latest_version — the version transit/sign uses by default — rather
+// than an arbitrary entry from the keys map, whose iteration order is
+// unspecified and, after any rotation, holds more than one version.
+func fetchTransitPublicKey(client *vaultapi.Client, keyName string) (crypto.PublicKey, error) {
+	secret, err := client.Logical().Read(fmt.Sprintf("transit/keys/%s", keyName))
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("no such transit key")
+	}
+
+	latestVersion, err := toInt(secret.Data["latest_version"])
+	if err != nil {
+		return nil, fmt.Errorf("reading latest_version: %w", err)
+	}
+
+	keys, _ := secret.Data["keys"].(map[string]interface{})
+	version, ok := keys[strconv.Itoa(latestVersion)].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("transit key has no version %d", latestVersion)
+	}
+
+	pemBlock, _ := version["public_key"].(string)
+	if pemBlock == "" {
+		return nil, fmt.Errorf("version %d has no public_key", latestVersion)
+	}
+
+	block, _ := pem.Decode([]byte(pemBlock))
+	if block == nil {
+		return nil, fmt.Errorf("version %d's public_key is not valid PEM", latestVersion)
+	}
+
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// toInt converts a JSON-decoded number to an int, handling both the
+// float64 the standard decoder produces and the json.Number some API
+// clients configure themselves to use instead.
+func toInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err
+	case float64:
+		return int(n), nil
+	default:
+		return 0, fmt.Errorf("unexpected type %T", v)
+	}
+}
+
+func renewVaultTokenLoop(ctx context.Context, client *vaultapi.Client, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_, _ = client.Auth().Token().RenewSelf(int(2 * interval / time.Second))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package signer
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// newTestVaultClient starts a stub Vault server backed by handler and
+// returns a *vaultapi.Client pointed at it.
+func newTestVaultClient(t *testing.T, handler http.Handler) *vaultapi.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg := vaultapi.DefaultConfig()
+	cfg.Address = srv.URL
+	client, err := vaultapi.NewClient(cfg)
+	if err != nil {
+		t.Fatalf("vaultapi.NewClient: %v", err)
+	}
+	return client
+}
+
+func testTransitPublicKeyPEM(t *testing.T) (pub []byte, key *rsa.PrivateKey) {
+	t.Helper()
+
+	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating test key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&pk.PublicKey)
+	if err != nil {
+		t.Fatalf("marshaling public key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), pk
+}
+
+func TestTransitSignerSignSendsConfiguredSignatureAlgorithm(t *testing.T) {
+	pubPEM, _ := testTransitPublicKeyPEM(t)
+
+	var gotSigAlg string
+	var sawSigAlg bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/transit/sign/my-key", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decoding sign request: %v", err)
+		}
+		gotSigAlg, sawSigAlg = body["signature_algorithm"].(string)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"signature": "vault:v1:" + base64.StdEncoding.EncodeToString([]byte("sig")),
+			},
+		})
+	})
+
+	client := newTestVaultClient(t, mux)
+
+	s := &transitSigner{client: client, keyName: "my-key", pub: pubPEM, alg: jose.RS256}
+	if _, err := s.Sign(nil, []byte("digest"), nil); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if !sawSigAlg {
+		t.Fatal("expected signature_algorithm to be sent for RS256")
+	}
+	if gotSigAlg != "pkcs1v15" {
+		t.Fatalf("expected pkcs1v15 for RS256, got %q", gotSigAlg)
+	}
+}
+
+func TestTransitSignerSignSendsPSSForPSAlgorithm(t *testing.T) {
+	var gotSigAlg string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/transit/sign/my-key", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decoding sign request: %v", err)
+		}
+		gotSigAlg, _ = body["signature_algorithm"].(string)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"signature": "vault:v1:" + base64.StdEncoding.EncodeToString([]byte("sig")),
+			},
+		})
+	})
+
+	client := newTestVaultClient(t, mux)
+
+	s := &transitSigner{client: client, keyName: "my-key", alg: jose.PS256}
+	if _, err := s.Sign(nil, []byte("digest"), nil); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if gotSigAlg != "pss" {
+		t.Fatalf("expected pss for PS256, got %q", gotSigAlg)
+	}
+}
+
+func TestFetchTransitPublicKeySelectsLatestVersion(t *testing.T) {
+	pubPEM, _ := testTransitPublicKeyPEM(t)
+	olderPEM, _ := testTransitPublicKeyPEM(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/transit/keys/my-key", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"latest_version": 2,
+				"keys": map[string]interface{}{
+					"1": map[string]interface{}{"public_key": string(olderPEM)},
+					"2": map[string]interface{}{"public_key": string(pubPEM)},
+				},
+			},
+		})
+	})
+
+	client := newTestVaultClient(t, mux)
+
+	got, err := fetchTransitPublicKey(client, "my-key")
+	if err != nil {
+		t.Fatalf("fetchTransitPublicKey: %v", err)
+	}
+
+	block, _ := pem.Decode(pubPEM)
+	want, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("parsing expected public key: %v", err)
+	}
+
+	gotDER, err := x509.MarshalPKIXPublicKey(got)
+	if err != nil {
+		t.Fatalf("marshaling got public key: %v", err)
+	}
+	wantDER, err := x509.MarshalPKIXPublicKey(want)
+	if err != nil {
+		t.Fatalf("marshaling want public key: %v", err)
+	}
+	if string(gotDER) != string(wantDER) {
+		t.Fatal("fetchTransitPublicKey did not select the latest_version's public key")
+	}
+}
+
+func TestVaultSignatureAlgorithm(t *testing.T) {
+	cases := []struct {
+		alg    jose.SignatureAlgorithm
+		want   string
+		wantOK bool
+	}{
+		{jose.RS256, "pkcs1v15", true},
+		{jose.RS384, "pkcs1v15", true},
+		{jose.RS512, "pkcs1v15", true},
+		{jose.PS256, "pss", true},
+		{jose.PS384, "pss", true},
+		{jose.PS512, "pss", true},
+		{jose.ES256, "", false},
+	}
+	for _, c := range cases {
+		t.Run(string(c.alg), func(t *testing.T) {
+			got, ok := vaultSignatureAlgorithm(c.alg)
+			if got != c.want || ok != c.wantOK {
+				t.Fatalf("vaultSignatureAlgorithm(%s) = (%q, %v), want (%q, %v)", c.alg, got, ok, c.want, c.wantOK)
+			}
+		})
+	}
+}
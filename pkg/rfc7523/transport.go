@@ -0,0 +1,103 @@
+package rfc7523
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http2"
+)
+
+// TransportConfig configures the underlying HTTP transport used to reach
+// the token endpoint: TLS client authentication (mTLS, RFC 8705), a
+// custom trusted root CA bundle, SNI override, HTTP/2, and proxy
+// behavior. The zero value yields a transport that uses the system trust
+// store, honors the proxy environment variables and negotiates HTTP/2; it
+// does not copy http.DefaultTransport's dial, TLS handshake and idle
+// connection timeouts or its connection pool limits.
+type TransportConfig struct {
+	// ClientCertFile and ClientKeyFile, if both set, are loaded as the
+	// TLS client certificate presented to the token endpoint, e.g. to
+	// satisfy Keycloak's "client-x509" authenticator (RFC 8705).
+	ClientCertFile string
+	ClientKeyFile  string
+
+	// RootCAFile, if set, replaces the system trust store with the PEM
+	// bundle at this path when verifying the token endpoint's
+	// certificate.
+	RootCAFile string
+
+	// ServerName overrides the SNI/verification hostname sent to the
+	// token endpoint, useful when it's reached through a proxy or by
+	// an IP address.
+	ServerName string
+
+	// DisableHTTP2 turns off HTTP/2 negotiation, for token endpoints
+	// that don't support it cleanly.
+	DisableHTTP2 bool
+
+	// Proxy selects the proxy used per request. Defaults to
+	// http.ProxyFromEnvironment (honoring HTTPS_PROXY/HTTP_PROXY/NO_PROXY).
+	Proxy func(*http.Request) (*url.URL, error)
+}
+
+// BuildTransport builds an *http.Transport from cfg, suitable for use as
+// Config.Transport.
+func (cfg TransportConfig) BuildTransport() (*http.Transport, error) {
+	tlsConfig := &tls.Config{ServerName: cfg.ServerName}
+
+	if cfg.ClientCertFile != "" || cfg.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("rfc7523: loading client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.RootCAFile != "" {
+		pemBytes, err := ioutil.ReadFile(cfg.RootCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("rfc7523: reading root CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("rfc7523: no certificates found in %s", cfg.RootCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	proxy := cfg.Proxy
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+
+	transport := &http.Transport{
+		Proxy:           proxy,
+		TLSClientConfig: tlsConfig,
+	}
+
+	if cfg.DisableHTTP2 {
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	} else if err := http2.ConfigureTransport(transport); err != nil {
+		return nil, fmt.Errorf("rfc7523: configuring HTTP/2: %w", err)
+	}
+
+	return transport, nil
+}
+
+// WithTLSClientAuth returns a copy of cfg that additionally presents the
+// TLS client certificate described by tlsCfg when reaching the token
+// endpoint, combining the JWT client assertion with mTLS for
+// authorization servers (e.g. Keycloak's "client-x509" authenticator)
+// that expect both.
+func (cfg Config) WithTLSClientAuth(tlsCfg TransportConfig) (Config, error) {
+	transport, err := tlsCfg.BuildTransport()
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.Transport = transport
+	return cfg, nil
+}